@@ -0,0 +1,118 @@
+// Package adservices verifies Apple AdServices attribution tokens: the opaque token that
+// AAAttribution.attributionToken() produces on iOS 14.3 and later. This is Apple's other
+// attribution path alongside SKAdNetwork, so a caller handling both can use AttributionRecord
+// and skadnetwork.Postback side by side instead of building two separate surfaces.
+//
+// https://developer.apple.com/documentation/adservices
+package adservices
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mechiru/skadnetwork"
+)
+
+// DefaultEndpoint is Apple's AdServices attribution API endpoint.
+const DefaultEndpoint = "https://api-adservices.apple.com/api/v1/"
+
+// AttributionRecord is the JSON payload Apple returns for a valid attribution token.
+type AttributionRecord struct {
+	Attribution     bool       `json:"attribution"`
+	OrgID           int64      `json:"orgId"`
+	CampaignID      int64      `json:"campaignId"`
+	ConversionType  string     `json:"conversionType"`
+	ClickDate       *time.Time `json:"clickDate,omitempty"`
+	AdGroupID       int64      `json:"adGroupId"`
+	KeywordID       int64      `json:"keywordId"`
+	AdID            int64      `json:"adId"`
+	CountryOrRegion string     `json:"countryOrRegion"`
+}
+
+// MatchesPostback reports whether r and p describe the same Apple Search Ads campaign, so a
+// caller that received both attribution paths for an install can correlate them.
+func (r *AttributionRecord) MatchesPostback(p *skadnetwork.Postback) bool {
+	return r.CampaignID == int64(p.CampaignID)
+}
+
+// Client verifies AdServices attribution tokens against Apple's endpoint.
+type Client struct {
+	// HTTPClient is used to call Endpoint; http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// Endpoint overrides DefaultEndpoint, mainly for tests.
+	Endpoint string
+	// MaxRetries is how many additional attempts are made after a 5xx response.
+	MaxRetries int
+	// Backoff is the base delay before a retry; it doubles on each subsequent attempt.
+	Backoff time.Duration
+}
+
+// NewClient returns a Client with Apple's endpoint and reasonable retry defaults.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient: http.DefaultClient,
+		Endpoint:   DefaultEndpoint,
+		MaxRetries: 3,
+		Backoff:    200 * time.Millisecond,
+	}
+}
+
+// Verify POSTs token to c.Endpoint and decodes the resulting AttributionRecord, retrying
+// with backoff on a 5xx response.
+func (c *Client) Verify(ctx context.Context, token string) (*AttributionRecord, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.Backoff << (attempt - 1)):
+			}
+		}
+
+		record, retry, err := c.verifyOnce(ctx, httpClient, token)
+		if !retry {
+			return record, err
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("adservices: exhausted retries: %w", lastErr)
+}
+
+// verifyOnce makes a single attempt. retry is true only for a 5xx response, which is the
+// only case Verify retries.
+func (c *Client) verifyOnce(ctx context.Context, httpClient *http.Client, token string) (record *AttributionRecord, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, strings.NewReader(token))
+	if err != nil {
+		return nil, false, fmt.Errorf("adservices: build request error: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("adservices: request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, true, fmt.Errorf("adservices: server error: %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("adservices: unexpected status: %s", resp.Status)
+	}
+
+	var r AttributionRecord
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, false, fmt.Errorf("adservices: response decode error: %w", err)
+	}
+	return &r, false, nil
+}
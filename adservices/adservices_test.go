@@ -0,0 +1,87 @@
+package adservices_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/mechiru/skadnetwork"
+	"github.com/mechiru/skadnetwork/adservices"
+)
+
+func TestClientVerify(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"attribution": true,
+			"orgId": 1234567890,
+			"campaignId": 42,
+			"conversionType": "Download",
+			"adGroupId": 1111,
+			"keywordId": 2222,
+			"adId": 3333,
+			"countryOrRegion": "US"
+		}`))
+	}))
+	defer srv.Close()
+
+	c := adservices.NewClient()
+	c.Endpoint = srv.URL
+
+	record, err := c.Verify(context.Background(), "opaque-token")
+	assert.NilError(t, err)
+	assert.Equal(t, record.Attribution, true)
+	assert.Equal(t, record.CampaignID, int64(42))
+	assert.Equal(t, record.CountryOrRegion, "US")
+}
+
+func TestClientVerifyRetriesOn5xx(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"attribution": true, "campaignId": 42}`))
+	}))
+	defer srv.Close()
+
+	c := adservices.NewClient()
+	c.Endpoint = srv.URL
+	c.Backoff = time.Millisecond
+
+	record, err := c.Verify(context.Background(), "opaque-token")
+	assert.NilError(t, err)
+	assert.Equal(t, record.CampaignID, int64(42))
+	assert.Equal(t, atomic.LoadInt32(&attempts), int32(3))
+}
+
+func TestClientVerifyGivesUpAfterMaxRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := adservices.NewClient()
+	c.Endpoint = srv.URL
+	c.Backoff = time.Millisecond
+	c.MaxRetries = 1
+
+	_, err := c.Verify(context.Background(), "opaque-token")
+	assert.Check(t, err != nil)
+}
+
+func TestAttributionRecordMatchesPostback(t *testing.T) {
+	r := &adservices.AttributionRecord{CampaignID: 42}
+	p := &skadnetwork.Postback{CampaignID: 42}
+	assert.Equal(t, r.MatchesPostback(p), true)
+
+	p.CampaignID = 43
+	assert.Equal(t, r.MatchesPostback(p), false)
+}
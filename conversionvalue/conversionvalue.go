@@ -0,0 +1,180 @@
+// Package conversionvalue is the server-side counterpart to skadnetwork's signature
+// verification: it maps an app's events into the 6-bit fine-conversion-value and 3-level
+// coarse-conversion-value that the app passes to SKAdNetwork.updatePostbackConversionValue,
+// so the values that later appear in a postback verified by skadnetwork.Verify come from a
+// single, versioned mapping definition instead of being hand-rolled in each app.
+//
+// https://developer.apple.com/documentation/storekit/skadnetwork/4.0/configuring-the-tree-structure-for-postbacks-with-hierarchical-conversion-values
+package conversionvalue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mechiru/skadnetwork"
+)
+
+// CoarseValue is an alias for skadnetwork.CoarseConversionValue, so callers of this package
+// don't need to import skadnetwork just to hold the value that Mapper.Map returns.
+type CoarseValue = skadnetwork.CoarseConversionValue
+
+// Event is a single app event. Count and Timestamp are carried through for callers that log
+// or bucket on them directly; Map itself only aggregates Revenue and neither enforces the
+// Schema's Window against Timestamp nor factors Count into a match — see Mapper.Map.
+type Event struct {
+	Name      string
+	Revenue   float64
+	Count     int
+	Timestamp time.Time
+}
+
+// Bucket assigns Fine to events named Name whose cumulative Revenue within the window
+// falls in [Min, Max).
+type Bucket struct {
+	Name string  `json:"name" yaml:"name"`
+	Min  float64 `json:"min" yaml:"min"`
+	Max  float64 `json:"max" yaml:"max"`
+	Fine uint8   `json:"fine" yaml:"fine"`
+}
+
+func (b Bucket) matches(seen map[string]bool, revenueByName map[string]float64) bool {
+	if !seen[b.Name] {
+		return false
+	}
+	r := revenueByName[b.Name]
+	return r >= b.Min && r < b.Max
+}
+
+// CoarseRange derives a CoarseValue from a fine-conversion-value range [Min, Max].
+type CoarseRange struct {
+	Min   uint8       `json:"min" yaml:"min"`
+	Max   uint8       `json:"max" yaml:"max"`
+	Value CoarseValue `json:"value" yaml:"value"`
+}
+
+func (r CoarseRange) matches(fine uint8) bool {
+	return fine >= r.Min && fine <= r.Max
+}
+
+// Schema describes, for a single app, how a window of Events becomes the tuple an app
+// passes to updatePostbackConversionValue. Of the Buckets that match, the one with the
+// highest Fine wins, whatever its position in Buckets.
+type Schema struct {
+	// Name identifies this schema, e.g. for logging or when several apps share a Mapper.
+	Name string `json:"name" yaml:"name"`
+	// Window is how long after install events are eligible to affect the conversion value.
+	// It documents the contract events must already satisfy by the time they reach Map: the
+	// caller, not Map, is responsible for excluding events observed after Window has elapsed.
+	Window time.Duration `json:"window" yaml:"window"`
+	// LockWindow is how long the app should wait, after setting the conversion value,
+	// before Apple locks it in and schedules the corresponding postback.
+	LockWindow time.Duration `json:"lock_window" yaml:"lock_window"`
+	// Buckets maps event revenue to a fine-conversion-value.
+	Buckets []Bucket `json:"buckets" yaml:"buckets"`
+	// CoarseRanges maps a resolved fine-conversion-value to a coarse-conversion-value.
+	// A fine value that matches no range falls back to CoarseConversionValueLow.
+	CoarseRanges []CoarseRange `json:"coarse_ranges" yaml:"coarse_ranges"`
+}
+
+// LoadSchemaJSON reads a Schema from JSON, so a mapping can be deployed or updated without
+// recompiling the ad network's attribution server.
+func LoadSchemaJSON(data []byte) (Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Schema{}, fmt.Errorf("conversionvalue: json schema decode error: %w", err)
+	}
+	return s, nil
+}
+
+// LoadSchemaYAML reads a Schema from YAML.
+func LoadSchemaYAML(data []byte) (Schema, error) {
+	var s Schema
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return Schema{}, fmt.Errorf("conversionvalue: yaml schema decode error: %w", err)
+	}
+	return s, nil
+}
+
+func (s Schema) coarseFor(fine uint8) CoarseValue {
+	for _, r := range s.CoarseRanges {
+		if r.matches(fine) {
+			return r.Value
+		}
+	}
+	return skadnetwork.CoarseConversionValueLow
+}
+
+// Mapper turns a window of Events into the conversion value tuple an app should pass to
+// SKAdNetwork.updatePostbackConversionValue.
+type Mapper struct {
+	schema Schema
+}
+
+// NewMapper returns a Mapper that applies schema to every call to Map.
+func NewMapper(schema Schema) *Mapper {
+	return &Mapper{schema: schema}
+}
+
+// Map selects the fine- and coarse-conversion-value for events the caller has already scoped
+// to the schema's Window of the app's install, along with the LockWindow the app should wait
+// before the value is considered final. Map does not filter events itself: it trusts that the
+// caller excluded anything outside Window before calling Map, and aggregates Revenue only, so
+// a schema that needs to match on event count rather than revenue isn't expressible yet.
+func (m *Mapper) Map(events []Event) (fine uint8, coarse CoarseValue, lockWindow time.Duration) {
+	// seen tracks which event names actually occurred, since a name with zero cumulative
+	// revenue that never occurred must not match a bucket with Min: 0.
+	seen := make(map[string]bool, len(events))
+	revenueByName := make(map[string]float64, len(events))
+	for _, e := range events {
+		seen[e.Name] = true
+		revenueByName[e.Name] += e.Revenue
+	}
+
+	for _, b := range m.schema.Buckets {
+		if b.matches(seen, revenueByName) && b.Fine >= fine {
+			fine = b.Fine
+		}
+	}
+	coarse = m.schema.coarseFor(fine)
+	lockWindow = m.schema.LockWindow
+	return fine, coarse, lockWindow
+}
+
+// QuantileBuckets computes Min/Max revenue boundaries for name by splitting the sorted
+// historical ltv values into quantiles evenly sized quantiles, assigning fine values
+// 1..min(quantiles, 63) in ascending order. It's a starting point for a Schema's Buckets,
+// not a replacement for tuning against real postback data.
+func QuantileBuckets(name string, ltv []float64, quantiles int) []Bucket {
+	if quantiles <= 0 || len(ltv) == 0 {
+		return nil
+	}
+	if quantiles > 63 {
+		quantiles = 63
+	}
+
+	sorted := append([]float64(nil), ltv...)
+	sort.Float64s(sorted)
+
+	boundaries := make([]float64, 0, quantiles+1)
+	boundaries = append(boundaries, sorted[0])
+	for i := 1; i < quantiles; i++ {
+		idx := i * (len(sorted) - 1) / quantiles
+		boundaries = append(boundaries, sorted[idx])
+	}
+	boundaries = append(boundaries, sorted[len(sorted)-1]+1)
+
+	buckets := make([]Bucket, 0, quantiles)
+	for i := 0; i < quantiles; i++ {
+		buckets = append(buckets, Bucket{
+			Name: name,
+			Min:  boundaries[i],
+			Max:  boundaries[i+1],
+			Fine: uint8(i + 1),
+		})
+	}
+	return buckets
+}
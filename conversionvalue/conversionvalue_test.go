@@ -0,0 +1,93 @@
+package conversionvalue_test
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/mechiru/skadnetwork"
+	"github.com/mechiru/skadnetwork/conversionvalue"
+)
+
+func TestMapperMap(t *testing.T) {
+	schema := conversionvalue.Schema{
+		Name:       "game",
+		Window:     24 * time.Hour,
+		LockWindow: time.Hour,
+		Buckets: []conversionvalue.Bucket{
+			{Name: "purchase", Min: 0, Max: 5, Fine: 1},
+			{Name: "purchase", Min: 5, Max: 50, Fine: 2},
+			{Name: "subscribe", Min: 0, Max: 1, Fine: 3},
+		},
+		CoarseRanges: []conversionvalue.CoarseRange{
+			{Min: 0, Max: 1, Value: skadnetwork.CoarseConversionValueLow},
+			{Min: 2, Max: 2, Value: skadnetwork.CoarseConversionValueMedium},
+			{Min: 3, Max: 63, Value: skadnetwork.CoarseConversionValueHigh},
+		},
+	}
+	m := conversionvalue.NewMapper(schema)
+
+	for _, c := range []struct {
+		name       string
+		events     []conversionvalue.Event
+		wantFine   uint8
+		wantCoarse conversionvalue.CoarseValue
+	}{
+		{
+			name:       "no events",
+			events:     nil,
+			wantFine:   0,
+			wantCoarse: skadnetwork.CoarseConversionValueLow,
+		},
+		{
+			name: "small purchase",
+			events: []conversionvalue.Event{
+				{Name: "purchase", Revenue: 2.5, Count: 1, Timestamp: time.Unix(0, 0)},
+			},
+			wantFine:   1,
+			wantCoarse: skadnetwork.CoarseConversionValueLow,
+		},
+		{
+			name: "subscribe outranks purchase",
+			events: []conversionvalue.Event{
+				{Name: "purchase", Revenue: 2.5, Count: 1, Timestamp: time.Unix(0, 0)},
+				{Name: "subscribe", Revenue: 0, Count: 1, Timestamp: time.Unix(1, 0)},
+			},
+			wantFine:   3,
+			wantCoarse: skadnetwork.CoarseConversionValueHigh,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			fine, coarse, lockWindow := m.Map(c.events)
+			assert.Equal(t, fine, c.wantFine)
+			assert.Equal(t, coarse, c.wantCoarse)
+			assert.Equal(t, lockWindow, time.Hour)
+		})
+	}
+}
+
+func TestQuantileBuckets(t *testing.T) {
+	ltv := []float64{0, 1, 2, 5, 10, 20, 50, 100}
+	buckets := conversionvalue.QuantileBuckets("purchase", ltv, 4)
+	assert.Equal(t, len(buckets), 4)
+	assert.Equal(t, buckets[0].Fine, uint8(1))
+	assert.Equal(t, buckets[3].Fine, uint8(4))
+	assert.Equal(t, buckets[0].Min, 0.0)
+	assert.Equal(t, buckets[3].Max, 101.0)
+}
+
+func TestLoadSchemaJSON(t *testing.T) {
+	data := []byte(`{
+		"name": "game",
+		"window": 86400000000000,
+		"lock_window": 3600000000000,
+		"buckets": [{"name": "purchase", "min": 0, "max": 5, "fine": 1}],
+		"coarse_ranges": [{"min": 0, "max": 63, "value": "low"}]
+	}`)
+	s, err := conversionvalue.LoadSchemaJSON(data)
+	assert.NilError(t, err)
+	assert.Equal(t, s.Name, "game")
+	assert.Equal(t, s.Window, 24*time.Hour)
+	assert.Equal(t, len(s.Buckets), 1)
+}
@@ -0,0 +1,61 @@
+// Package awskms adapts an AWS KMS asymmetric ECDSA_SHA_256 signing key to
+// skadnetwork.KeySource, so a Signer can keep its key in KMS instead of on disk.
+package awskms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+
+	"github.com/mechiru/skadnetwork"
+)
+
+// KeySource signs through an AWS KMS asymmetric key of spec ECC_NIST_P256 and usage
+// SIGN_VERIFY.
+type KeySource struct {
+	client *kms.Client
+	keyID  string
+	public *ecdsa.PublicKey
+}
+
+var _ skadnetwork.KeySource = (*KeySource)(nil)
+
+// New fetches keyID's public key from client and returns a KeySource that signs with it.
+func New(ctx context.Context, client *kms.Client, keyID string) (*KeySource, error) {
+	out, err := client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: &keyID})
+	if err != nil {
+		return nil, fmt.Errorf("awskms: get public key error: %w", err)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(out.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("awskms: parse public key error: %w", err)
+	}
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("awskms: key %s is not an ecdsa public key", keyID)
+	}
+
+	return &KeySource{client: client, keyID: keyID, public: ecdsaPub}, nil
+}
+
+func (k *KeySource) Public() *ecdsa.PublicKey {
+	return k.public
+}
+
+func (k *KeySource) SignASN1(ctx context.Context, hash []byte) ([]byte, error) {
+	out, err := k.client.Sign(ctx, &kms.SignInput{
+		KeyId:            &k.keyID,
+		Message:          hash,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: types.SigningAlgorithmSpecEcdsaSha256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("awskms: sign error: %w", err)
+	}
+	return out.Signature, nil
+}
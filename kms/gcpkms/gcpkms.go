@@ -0,0 +1,65 @@
+// Package gcpkms adapts a Google Cloud KMS asymmetric EC_SIGN_P256_SHA256 signing key to
+// skadnetwork.KeySource, so a Signer can keep its key in Cloud KMS instead of on disk.
+package gcpkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+
+	"github.com/mechiru/skadnetwork"
+)
+
+// KeySource signs through a Cloud KMS CryptoKeyVersion of algorithm
+// EC_SIGN_P256_SHA256.
+type KeySource struct {
+	client *kms.KeyManagementClient
+	name   string // projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*
+	public *ecdsa.PublicKey
+}
+
+var _ skadnetwork.KeySource = (*KeySource)(nil)
+
+// New fetches the public key for the CryptoKeyVersion named name and returns a KeySource
+// that signs with it.
+func New(ctx context.Context, client *kms.KeyManagementClient, name string) (*KeySource, error) {
+	resp, err := client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: get public key error: %w", err)
+	}
+
+	block, _ := pem.Decode([]byte(resp.Pem))
+	if block == nil {
+		return nil, fmt.Errorf("gcpkms: public key %s is not PEM-encoded", name)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: parse public key error: %w", err)
+	}
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("gcpkms: key %s is not an ecdsa public key", name)
+	}
+
+	return &KeySource{client: client, name: name, public: ecdsaPub}, nil
+}
+
+func (k *KeySource) Public() *ecdsa.PublicKey {
+	return k.public
+}
+
+func (k *KeySource) SignASN1(ctx context.Context, hash []byte) ([]byte, error) {
+	resp, err := k.client.AsymmetricSign(ctx, &kmspb.AsymmetricSignRequest{
+		Name:   k.name,
+		Digest: &kmspb.Digest{Digest: &kmspb.Digest_Sha256{Sha256: hash}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: sign error: %w", err)
+	}
+	return resp.Signature, nil
+}
@@ -0,0 +1,69 @@
+// Package pkcs11hsm adapts an ECDSA P-256 key held on a PKCS#11 token (an HSM) to
+// skadnetwork.KeySource, so a Signer can keep its key on hardware instead of on disk.
+package pkcs11hsm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/miekg/pkcs11"
+
+	"github.com/mechiru/skadnetwork"
+)
+
+// KeySource signs through an ECDSA P-256 private key object on a PKCS#11 token. The caller
+// is responsible for opening ctx and logging session in with the key's access PIN.
+type KeySource struct {
+	ctx              *pkcs11.Ctx
+	session          pkcs11.SessionHandle
+	privateKeyHandle pkcs11.ObjectHandle
+	public           *ecdsa.PublicKey
+}
+
+var _ skadnetwork.KeySource = (*KeySource)(nil)
+
+// New returns a KeySource that signs with privateKeyHandle over session. public is the
+// public half of that key, read from the token (or its certificate) ahead of time, since
+// PKCS#11 has no single portable call to recover it from a private key handle.
+func New(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, privateKeyHandle pkcs11.ObjectHandle, public *ecdsa.PublicKey) *KeySource {
+	return &KeySource{ctx: ctx, session: session, privateKeyHandle: privateKeyHandle, public: public}
+}
+
+func (k *KeySource) Public() *ecdsa.PublicKey {
+	return k.public
+}
+
+func (k *KeySource) SignASN1(_ context.Context, hash []byte) ([]byte, error) {
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}
+	if err := k.ctx.SignInit(k.session, mechanism, k.privateKeyHandle); err != nil {
+		return nil, fmt.Errorf("pkcs11hsm: sign init error: %w", err)
+	}
+
+	// PKCS#11's C_Sign for CKM_ECDSA returns the raw, fixed-length r || s pair, not the
+	// ASN.1 DER encoding skadnetwork.Verify expects.
+	raw, err := k.ctx.Sign(k.session, hash)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11hsm: sign error: %w", err)
+	}
+	return rawToASN1(raw)
+}
+
+func rawToASN1(raw []byte) ([]byte, error) {
+	if len(raw)%2 != 0 {
+		return nil, fmt.Errorf("pkcs11hsm: raw signature has odd length: %d", len(raw))
+	}
+	n := len(raw) / 2
+
+	sig := struct{ R, S *big.Int }{
+		R: new(big.Int).SetBytes(raw[:n]),
+		S: new(big.Int).SetBytes(raw[n:]),
+	}
+	der, err := asn1.Marshal(sig)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11hsm: asn1 encode error: %w", err)
+	}
+	return der, nil
+}
@@ -0,0 +1,35 @@
+package pkcs11hsm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRawToASN1(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	hash := make([]byte, 32)
+	_, err = rand.Read(hash)
+	assert.NilError(t, err)
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash)
+	assert.NilError(t, err)
+
+	raw := make([]byte, 64)
+	r.FillBytes(raw[:32])
+	s.FillBytes(raw[32:])
+
+	der, err := rawToASN1(raw)
+	assert.NilError(t, err)
+	assert.Check(t, ecdsa.VerifyASN1(&key.PublicKey, hash, der))
+}
+
+func TestRawToASN1OddLength(t *testing.T) {
+	_, err := rawToASN1([]byte{1, 2, 3})
+	assert.Check(t, err != nil)
+}
@@ -0,0 +1,45 @@
+// Package kmstest is the contract test every skadnetwork.KeySource implementation should
+// pass: sign a canonical skadnetwork.Params and verify the result, the same round trip
+// Signer.Sign / Signer.Verify does in production.
+package kmstest
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/google/uuid"
+
+	"github.com/mechiru/skadnetwork"
+)
+
+// RoundTrip signs a canonical Params through source and asserts that Signer.Verify accepts
+// the result. Call it from every KeySource implementation's own test with a source backed
+// by that implementation's signing key.
+func RoundTrip(t *testing.T, source skadnetwork.KeySource) {
+	t.Helper()
+
+	timestamp, err := time.Parse(time.RFC3339, "2022-05-06T10:00:00Z")
+	assert.NilError(t, err)
+
+	p := &skadnetwork.Params{
+		Version:          "3.0",
+		AdNetworkID:      "example123.skadnetwork",
+		CampaignID:       42,
+		ItunesItemID:     525463029,
+		Nonce:            uuid.MustParse("68483ef6-0ada-40df-ab6b-3d19a66330fa"),
+		SourceAppStoreID: 1234567891,
+		FidelityType:     skadnetwork.SKRenderedAds,
+		Timestamp:        timestamp,
+	}
+
+	signer := skadnetwork.NewSignerWithKeySource(source)
+
+	sig, err := signer.Sign(p)
+	assert.NilError(t, err)
+
+	ok, err := signer.Verify(p, sig)
+	assert.NilError(t, err)
+	assert.Equal(t, ok, true)
+}
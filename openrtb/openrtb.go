@@ -0,0 +1,186 @@
+// Package openrtb builds and parses the imp.ext.skadn object that OpenRTB bid
+// requests/responses use to carry SKAdNetwork data, as consumed by frameworks such as
+// AppLovin MAX and Prebid Server.
+//
+// https://github.com/InteractiveAdvertisingBureau/openrtb/blob/main/extensions/2.x_official_supplement/skadn.md
+package openrtb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mechiru/skadnetwork"
+)
+
+var itunesItemIDRe = regexp.MustCompile(`/id(\d+)`)
+
+// ParseItunesItemID extracts the App Store item id from a storeURL such as
+// "https://apps.apple.com/us/app/some-app/id525463029".
+func ParseItunesItemID(storeURL string) (int64, error) {
+	m := itunesItemIDRe.FindStringSubmatch(storeURL)
+	if m == nil {
+		return 0, fmt.Errorf("openrtb: no itunes item id found in store url: %s", storeURL)
+	}
+	id, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("openrtb: itunes item id parse error: %w", err)
+	}
+	return id, nil
+}
+
+// Response is the imp.ext.skadn object an ad network returns in an OpenRTB bid response,
+// across SKAdNetwork versions 2.2, 3.0 and 4.0. Fields that don't apply to Version are left
+// at their zero value and omitted from the JSON.
+type Response struct {
+	Version string `json:"version"`
+	Network string `json:"network"`
+	// Campaign is the numeric campaign id for versions before 4.0.
+	Campaign   int    `json:"campaign,omitempty"`
+	ITunesItem string `json:"itunesitem"`
+	// SourceApp is the App Store id of the app that displayed the ad, versions 2.2 and 3.0.
+	SourceApp string `json:"sourceapp,omitempty"`
+	// SourceIdentifier replaces SourceApp starting with version 4.0.
+	SourceIdentifier string `json:"sourceidentifier,omitempty"`
+	// SourceDomain is the domain of the web ad, version 4.0; it is set alongside
+	// SourceIdentifier and isn't covered by Signature.
+	SourceDomain string `json:"sourcedomain,omitempty"`
+	Nonce        string `json:"nonce"`
+	// Timestamp is UNIX time in milliseconds.
+	Timestamp string `json:"timestamp"`
+	Signature string `json:"signature"`
+}
+
+// BuildParams carries everything Build needs to produce a signed Response.
+type BuildParams struct {
+	// Version is the SKAdNetwork API version: "2.2", "3.0" or "4.0".
+	Version string
+	// AdNetworkID is the ad network identifier registered with Apple.
+	AdNetworkID string
+	// CampaignID is the numeric campaign id, used for Version "2.2" and "3.0".
+	CampaignID int
+	// SourceIdentifier is used in place of CampaignID for Version "4.0".
+	SourceIdentifier string
+	// StoreURL is the advertised app's App Store product page, e.g.
+	// "https://apps.apple.com/us/app/some-app/id525463029".
+	StoreURL string
+	// SourceAppStoreID is the App Store id of the app displaying the ad.
+	// During testing, use 0 if the displaying app isn't from the App Store.
+	SourceAppStoreID int64
+	// SourceDomain is the domain of the web ad, for web-to-app attribution, version 4.0.
+	SourceDomain string
+	Nonce        uuid.UUID
+	FidelityType skadnetwork.FidelityType
+	Timestamp    time.Time
+}
+
+// Build signs p with signer and returns the imp.ext.skadn object to place in an OpenRTB
+// bid response.
+func Build(signer *skadnetwork.Signer, p BuildParams) (*Response, error) {
+	itunesItemID, err := ParseItunesItemID(p.StoreURL)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := signer.Sign(&skadnetwork.Params{
+		Version:          p.Version,
+		AdNetworkID:      p.AdNetworkID,
+		CampaignID:       p.CampaignID,
+		SourceIdentifier: p.SourceIdentifier,
+		ItunesItemID:     itunesItemID,
+		Nonce:            p.Nonce,
+		SourceAppStoreID: p.SourceAppStoreID,
+		FidelityType:     p.FidelityType,
+		Timestamp:        p.Timestamp,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openrtb: sign response error: %w", err)
+	}
+
+	r := &Response{
+		Version:    p.Version,
+		Network:    p.AdNetworkID,
+		ITunesItem: strconv.FormatInt(itunesItemID, 10),
+		Nonce:      p.Nonce.String(),
+		Timestamp:  strconv.FormatInt(p.Timestamp.UnixMilli(), 10),
+		Signature:  sig,
+	}
+	switch p.Version {
+	case "4.0":
+		r.SourceIdentifier = p.SourceIdentifier
+		r.SourceDomain = p.SourceDomain
+	default:
+		r.Campaign = p.CampaignID
+		r.SourceApp = strconv.FormatInt(p.SourceAppStoreID, 10)
+	}
+	return r, nil
+}
+
+// Request is the imp.ext.skadn object an app sends in an OpenRTB bid request to advertise
+// SKAdNetwork support.
+type Request struct {
+	// Versions is the list of SKAdNetwork API versions the requesting app supports.
+	Versions []string `json:"versions,omitempty"`
+	// Version is the single-version form some integrations still send instead of Versions.
+	Version string `json:"version,omitempty"`
+	// SourceApp is the App Store id of the app making the request.
+	SourceApp string `json:"sourceapp,omitempty"`
+	// SKAdNetworkIDs is the list of ad-network-ids the app declared in its Info.plist.
+	SKAdNetworkIDs []string `json:"skadnetids,omitempty"`
+}
+
+// versions returns r's supported versions, normalizing the single-Version form.
+func (r Request) versions() []string {
+	if len(r.Versions) > 0 {
+		return r.Versions
+	}
+	if r.Version != "" {
+		return []string{r.Version}
+	}
+	return nil
+}
+
+// BestVersion returns the highest SKAdNetwork version that both r and supported declare,
+// so a DSP can pick the richest response layout both sides understand.
+func BestVersion(r Request, supported []string) (string, bool) {
+	supportedSet := make(map[string]bool, len(supported))
+	for _, v := range supported {
+		supportedSet[v] = true
+	}
+
+	best, ok := "", false
+	for _, v := range r.versions() {
+		if !supportedSet[v] {
+			continue
+		}
+		if !ok || compareVersion(v, best) > 0 {
+			best, ok = v, true
+		}
+	}
+	return best, ok
+}
+
+// compareVersion compares two "major.minor" SKAdNetwork version strings, returning a
+// negative, zero or positive number as a < b, a == b or a > b.
+func compareVersion(a, b string) int {
+	as, bs := strings.SplitN(a, ".", 2), strings.SplitN(b, ".", 2)
+	for i := 0; i < 2; i++ {
+		av, bv := versionPart(as, i), versionPart(bs, i)
+		if av != bv {
+			return av - bv
+		}
+	}
+	return 0
+}
+
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	n, _ := strconv.Atoi(parts[i])
+	return n
+}
@@ -0,0 +1,154 @@
+// Package postback provides an http.Handler that decodes, verifies and deduplicates
+// Apple's SKAdNetwork install-validation postbacks, so integrators don't each reimplement
+// the HTTP plumbing around skadnetwork.Verify.
+package postback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mechiru/skadnetwork"
+)
+
+// DefaultTTL is how long a transaction id is remembered for deduplication when Handler.TTL
+// is zero. Apple may retry a postback delivery, so this should comfortably exceed Apple's
+// retry window.
+const DefaultTTL = 7 * 24 * time.Hour
+
+// TransactionStore deduplicates postbacks by transaction id. Implementations must be safe
+// for concurrent use. MemoryStore below is the only implementation this package ships;
+// a production deployment with more than one Handler instance should instead back this
+// interface with Redis (SET NX with a TTL) or a SQL table keyed on transaction id with a
+// unique constraint and an expires_at column.
+type TransactionStore interface {
+	// Seen reports whether transactionID was already remembered.
+	Seen(ctx context.Context, transactionID string) (bool, error)
+	// Remember records transactionID so a later Seen call reports true, until ttl elapses.
+	Remember(ctx context.Context, transactionID string, ttl time.Duration) error
+}
+
+// Handler decodes an Apple SKAdNetwork postback from the request body, verifies its
+// signature and, once past deduplication and the optional ad-network allowlist, invokes
+// Handle. Handle's error (or a bad signature, or a duplicate) produces a non-2xx response;
+// Apple only stops retrying once it sees a 2xx.
+type Handler struct {
+	// Store deduplicates postbacks by transaction id. Required.
+	Store TransactionStore
+	// Handle is called with the verified postback. Required.
+	Handle func(ctx context.Context, p *skadnetwork.Postback) error
+	// AllowedAdNetworkIDs, if non-empty, restricts accepted postbacks to these
+	// ad-network-id values.
+	AllowedAdNetworkIDs []string
+	// TTL is how long a transaction id is remembered; DefaultTTL is used when zero.
+	TTL time.Duration
+}
+
+// NewHandler returns a Handler that deduplicates against store and, for every
+// newly-verified postback, calls handle.
+func NewHandler(store TransactionStore, handle func(ctx context.Context, p *skadnetwork.Postback) error) *Handler {
+	return &Handler{Store: store, Handle: handle}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var p skadnetwork.Postback
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, fmt.Sprintf("postback: decode request body error: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	ok, err := skadnetwork.Verify(p)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("postback: verify error: %s", err), http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		http.Error(w, "postback: invalid signature", http.StatusBadRequest)
+		return
+	}
+
+	if !h.allowed(p.AdNetworkID) {
+		http.Error(w, fmt.Sprintf("postback: ad-network-id not allowed: %s", p.AdNetworkID), http.StatusForbidden)
+		return
+	}
+
+	ctx := r.Context()
+	seen, err := h.Store.Seen(ctx, p.TransactionID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("postback: transaction store error: %s", err), http.StatusInternalServerError)
+		return
+	}
+	if seen {
+		http.Error(w, fmt.Sprintf("postback: duplicate transaction id: %s", p.TransactionID), http.StatusConflict)
+		return
+	}
+
+	if err := h.Handle(ctx, &p); err != nil {
+		http.Error(w, fmt.Sprintf("postback: handle error: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	ttl := h.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if err := h.Store.Remember(ctx, p.TransactionID, ttl); err != nil {
+		http.Error(w, fmt.Sprintf("postback: transaction store error: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) allowed(adNetworkID string) bool {
+	if len(h.AllowedAdNetworkIDs) == 0 {
+		return true
+	}
+	for _, id := range h.AllowedAdNetworkIDs {
+		if id == adNetworkID {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryStore is an in-memory TransactionStore, suitable for a single-instance deployment
+// or for tests. Expired entries are swept lazily on Seen and Remember.
+type MemoryStore struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{expires: make(map[string]time.Time)}
+}
+
+func (s *MemoryStore) Seen(_ context.Context, transactionID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+	_, ok := s.expires[transactionID]
+	return ok, nil
+}
+
+func (s *MemoryStore) Remember(_ context.Context, transactionID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+	s.expires[transactionID] = time.Now().Add(ttl)
+	return nil
+}
+
+// sweep removes expired entries. Callers must hold s.mu.
+func (s *MemoryStore) sweep() {
+	now := time.Now()
+	for id, exp := range s.expires {
+		if now.After(exp) {
+			delete(s.expires, id)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package postback_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/mechiru/skadnetwork"
+	"github.com/mechiru/skadnetwork/postback"
+)
+
+const v3_0__win = `{
+  "version": "3.0",
+  "ad-network-id": "example123.skadnetwork",
+  "campaign-id": 42,
+  "transaction-id": "6aafb7a5-0170-41b5-bbe4-fe71dedf1e28",
+  "app-id": 525463029,
+  "attribution-signature": "MEYCIQD5eq3AUlamORiGovqFiHWI4RZT/PrM3VEiXUrsC+M51wIhAPMANZA9c07raZJ64gVaXhB9+9yZj/X6DcNxONdccQij",
+  "redownload": true,
+  "source-app-id": 1234567891,
+  "fidelity-type": 1,
+  "conversion-value": 20,
+  "did-win": true
+}`
+
+func doRequest(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerServeHTTP(t *testing.T) {
+	t.Run("calls Handle on first delivery", func(t *testing.T) {
+		var got *skadnetwork.Postback
+		h := postback.NewHandler(postback.NewMemoryStore(), func(_ context.Context, p *skadnetwork.Postback) error {
+			got = p
+			return nil
+		})
+
+		rec := doRequest(t, h, v3_0__win)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, got.TransactionID, "6aafb7a5-0170-41b5-bbe4-fe71dedf1e28")
+	})
+
+	t.Run("rejects a replayed transaction id", func(t *testing.T) {
+		h := postback.NewHandler(postback.NewMemoryStore(), func(context.Context, *skadnetwork.Postback) error {
+			return nil
+		})
+
+		assert.Equal(t, doRequest(t, h, v3_0__win).Code, http.StatusOK)
+		assert.Equal(t, doRequest(t, h, v3_0__win).Code, http.StatusConflict)
+	})
+
+	t.Run("rejects a disallowed ad-network-id", func(t *testing.T) {
+		h := postback.NewHandler(postback.NewMemoryStore(), func(context.Context, *skadnetwork.Postback) error {
+			return nil
+		})
+		h.AllowedAdNetworkIDs = []string{"other.skadnetwork"}
+
+		assert.Equal(t, doRequest(t, h, v3_0__win).Code, http.StatusForbidden)
+	})
+
+	t.Run("returns 5xx so Apple retries when Handle fails", func(t *testing.T) {
+		h := postback.NewHandler(postback.NewMemoryStore(), func(context.Context, *skadnetwork.Postback) error {
+			return errors.New("handler failed")
+		})
+
+		assert.Equal(t, doRequest(t, h, v3_0__win).Code, http.StatusInternalServerError)
+	})
+
+	t.Run("rejects malformed json", func(t *testing.T) {
+		h := postback.NewHandler(postback.NewMemoryStore(), func(context.Context, *skadnetwork.Postback) error {
+			return nil
+		})
+
+		rec := doRequest(t, h, "not json")
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+
+	t.Run("rejects a postback missing a required field instead of panicking", func(t *testing.T) {
+		h := postback.NewHandler(postback.NewMemoryStore(), func(context.Context, *skadnetwork.Postback) error {
+			return nil
+		})
+
+		rec := doRequest(t, h, `{"version": "4.0", "ad-network-id": "example123.skadnetwork"}`)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := postback.NewMemoryStore()
+	ctx := context.Background()
+
+	seen, err := s.Seen(ctx, "tx-1")
+	assert.NilError(t, err)
+	assert.Equal(t, seen, false)
+
+	assert.NilError(t, s.Remember(ctx, "tx-1", 0))
+
+	seen, err = s.Seen(ctx, "tx-1")
+	assert.NilError(t, err)
+	assert.Equal(t, seen, false, "zero ttl should already be expired")
+}
@@ -1,6 +1,7 @@
 package skadnetwork
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/sha256"
@@ -17,7 +18,7 @@ import (
 )
 
 // Combine the values into a UTF-8 string with an invisible separator ('\u2063') between them.
-// Version: 1.0, 2.0, 2.1, 2.2, 3.0
+// Version: 1.0, 2.0, 2.1, 2.2, 3.0, 4.0
 const separator = string('\u2063')
 
 // Apple's public keys for postback:
@@ -46,6 +47,33 @@ func (f FidelityType) String() string {
 	return strconv.FormatInt(int64(f), 10)
 }
 
+// https://developer.apple.com/documentation/storekit/skadnetwork/4.0/configuring-the-tree-structure-for-postbacks-with-hierarchical-conversion-values
+type CoarseConversionValue string
+
+const (
+	CoarseConversionValueLow    CoarseConversionValue = "low"
+	CoarseConversionValueMedium CoarseConversionValue = "medium"
+	CoarseConversionValueHigh   CoarseConversionValue = "high"
+)
+
+func (c CoarseConversionValue) String() string {
+	return string(c)
+}
+
+// https://developer.apple.com/documentation/storekit/skadnetwork/4.0/
+// Identifies which postback in the SKAdNetwork 4.0 sequence (0, 1 or 2) this payload represents.
+type PostbackSequenceIndex int
+
+const (
+	PostbackSequenceIndex0 PostbackSequenceIndex = 0
+	PostbackSequenceIndex1 PostbackSequenceIndex = 1
+	PostbackSequenceIndex2 PostbackSequenceIndex = 2
+)
+
+func (i PostbackSequenceIndex) String() string {
+	return strconv.FormatInt(int64(i), 10)
+}
+
 // https://developer.apple.com/documentation/storekit/skadnetwork/generating_the_signature_to_validate_storekit-rendered_ads
 type Params struct {
 	// Version 2.0 and later.
@@ -71,23 +99,30 @@ type Params struct {
 	// Version 2.2 and later.
 	// A value of 0 indicates a view-through ad presentation; a value of 1 indicates a StoreKit-rendered ad.
 	FidelityType FidelityType `json:"fidelity-type,omitempty"`
+	// Version 4.0 and later.
+	// A 2-, 3-, or 4-digit source identifier, distinct from AdNetworkID, that replaces campaign-id.
+	SourceIdentifier string `json:"source-identifier,omitempty"`
 	// Version 1.0 and later.
 	// A timestamp you generate near the time of the ad impression.
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// https://developer.apple.com/documentation/storekit/skadnetwork/4.0/generating-the-signature-to-validate-storekit-rendered-ads
 func (p Params) toItems() []string {
 	items := make([]string, 0, 8)
+	items = append(items, p.Version, p.AdNetworkID)
+	if p.Version == "4.0" {
+		items = append(items, p.SourceIdentifier)
+	} else {
+		items = append(items, strconv.Itoa(p.CampaignID))
+	}
 	items = append(items,
-		p.Version,
-		p.AdNetworkID,
-		strconv.Itoa(p.CampaignID),
 		strconv.FormatInt(p.ItunesItemID, 10),
 		p.Nonce.String(),
 		strconv.FormatInt(p.SourceAppStoreID, 10),
 	)
 	switch p.Version {
-	case "2.2", "3.0":
+	case "2.2", "3.0", "4.0":
 		items = append(items, p.FidelityType.String())
 	}
 	// Represented as UNIX time in milliseconds.
@@ -139,6 +174,26 @@ type Postback struct {
 	// A Boolean value that’s true if the ad network won the attribution,
 	// and false if the postback represents a qualifying ad impression that didn’t win the attribution.
 	DidWin *bool `json:"did-win,omitempty"`
+	// Version 4.0 and later.
+	// A 2-, 3-, or 4-digit source identifier, distinct from ad-network-id, that replaces campaign-id.
+	SourceIdentifier string `json:"source-identifier,omitempty"`
+	// Version 4.0 and later.
+	// The domain of the web ad that led to the app download, present instead of source-app-id for web ads.
+	SourceDomain string `json:"source-domain,omitempty"`
+	// Version 4.0 and later.
+	// A Boolean value that’s true if source-domain matches the domain Apple verified for the ad network.
+	SourceDomainMatched *bool `json:"source-domain-matched,omitempty"`
+	// Version 4.0 and later.
+	// Identifies which of the (up to) three postbacks in the sequence this is: 0, 1, or 2.
+	PostbackSequenceIndex *PostbackSequenceIndex `json:"postback-sequence-index,omitempty"`
+	// Version 4.0 and later.
+	// A coarse-grained conversion value, present in place of or alongside fine-conversion-value
+	// depending on the app’s crowd anonymity.
+	CoarseConversionValue *CoarseConversionValue `json:"coarse-conversion-value,omitempty"`
+	// Version 4.0 and later.
+	// An unsigned 6-bit value the installed app provided by calling updatePostbackConversionValue(_:).
+	// Renamed from conversion-value; only present on the first postback (postback-sequence-index 0).
+	FineConversionValue *uint8 `json:"fine-conversion-value,omitempty"`
 }
 
 // For 2.1:
@@ -149,7 +204,14 @@ type Postback struct {
 //
 // For 3.0:
 // https://developer.apple.com/documentation/storekit/skadnetwork/verifying_an_install-validation_postback#2960703
+//
+// For 4.0:
+// https://developer.apple.com/documentation/storekit/skadnetwork/4.0/combining-parameters-for-postback-validation
 func (p Postback) toItems() []string {
+	if p.Version == "4.0" {
+		return p.toItemsV4()
+	}
+
 	ret := make([]string, 0, 9)
 	ret = append(ret,
 		p.Version,
@@ -171,30 +233,129 @@ func (p Postback) toItems() []string {
 	return ret
 }
 
+// toItemsV4 builds the signing string for version "4.0", whose layout diverges enough from
+// the 2.x/3.0 postbacks (no campaign-id, source-identifier instead, trailing
+// postback-sequence-index) that folding it into toItems would make that function unreadable.
+// app-id is always present, for both app and web ads; source-app-id is replaced by
+// source-domain only for web ads. source-domain-matched is informational and isn't signed.
+func (p Postback) toItemsV4() []string {
+	ret := make([]string, 0, 10)
+	ret = append(ret,
+		p.Version,
+		p.AdNetworkID,
+		p.SourceIdentifier,
+		strconv.FormatInt(p.AppID, 10),
+		p.TransactionID,
+		strconv.FormatBool(*p.Redownload),
+	)
+	if p.SourceDomain != "" {
+		ret = append(ret, p.SourceDomain)
+	} else if p.SourceAppID != nil {
+		ret = append(ret, strconv.FormatInt(*p.SourceAppID, 10))
+	}
+	ret = append(ret, p.FidelityType.String(), strconv.FormatBool(*p.DidWin), p.PostbackSequenceIndex.String())
+	return ret
+}
+
+// validate reports a missing field as an error instead of letting toItems dereference a nil
+// pointer, since a Postback decoded from an untrusted request body may be missing any of
+// them.
+func (p Postback) validate() error {
+	if p.Redownload == nil {
+		return errors.New("skadnetwork: missing required field: redownload")
+	}
+	switch p.Version {
+	case "2.2":
+		if p.FidelityType == nil {
+			return errors.New("skadnetwork: missing required field: fidelity-type")
+		}
+	case "3.0":
+		if p.FidelityType == nil {
+			return errors.New("skadnetwork: missing required field: fidelity-type")
+		}
+		if p.DidWin == nil {
+			return errors.New("skadnetwork: missing required field: did-win")
+		}
+	case "4.0":
+		if p.FidelityType == nil {
+			return errors.New("skadnetwork: missing required field: fidelity-type")
+		}
+		if p.DidWin == nil {
+			return errors.New("skadnetwork: missing required field: did-win")
+		}
+		if p.PostbackSequenceIndex == nil {
+			return errors.New("skadnetwork: missing required field: postback-sequence-index")
+		}
+	}
+	return nil
+}
+
 func (p Postback) verify() (bool, error) {
 	switch p.Version {
-	case "2.1", "2.2", "3.0":
+	case "2.1", "2.2", "3.0", "4.0":
+		if err := p.validate(); err != nil {
+			return false, err
+		}
 		return verify(pubV3, p.toItems(), p.AttributionSignature)
 	default:
 		return false, fmt.Errorf("skadnetwork: unsupported version error: %s", p.Version)
 	}
 }
 
-type Signer struct {
+// KeySource produces ECDSA P-256 signatures for Signer, so the signing key doesn't have to
+// live on disk as a raw PEM file. NewPEMKeySource is the PEM-backed implementation Signer
+// used to use directly; see the kms subpackages for KMS/HSM-backed ones.
+type KeySource interface {
+	// Public returns the public half of the signing key.
+	Public() *ecdsa.PublicKey
+	// SignASN1 returns an ASN.1 DER-encoded ECDSA signature over hash, which is always a
+	// SHA-256 digest.
+	SignASN1(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+type pemKeySource struct {
 	key *ecdsa.PrivateKey
 }
 
-func NewSigner(pem string) (*Signer, error) {
+// NewPEMKeySource returns a KeySource backed by an EC private key PEM block, in the same
+// format NewSigner previously accepted directly.
+func NewPEMKeySource(pem string) (KeySource, error) {
 	key, err := decodePEM(pem)
 	if err != nil {
 		return nil, fmt.Errorf("skadnetwork: pem data decode error: %w", err)
 	}
-	return &Signer{key: key}, nil
+	return &pemKeySource{key: key}, nil
 }
 
-func (s *Signer) sign(msg string) (string, error) {
-	hash := hash(msg)
-	der, err := ecdsa.SignASN1(rand.Reader, s.key, hash)
+func (s *pemKeySource) Public() *ecdsa.PublicKey {
+	return &s.key.PublicKey
+}
+
+func (s *pemKeySource) SignASN1(_ context.Context, hash []byte) ([]byte, error) {
+	return ecdsa.SignASN1(rand.Reader, s.key, hash)
+}
+
+type Signer struct {
+	source KeySource
+}
+
+// NewSigner returns a Signer backed by the EC private key in pem.
+func NewSigner(pem string) (*Signer, error) {
+	source, err := NewPEMKeySource(pem)
+	if err != nil {
+		return nil, err
+	}
+	return NewSignerWithKeySource(source), nil
+}
+
+// NewSignerWithKeySource returns a Signer that signs through source, e.g. a KMS or HSM
+// adapter from one of the kms subpackages instead of a key held in process memory.
+func NewSignerWithKeySource(source KeySource) *Signer {
+	return &Signer{source: source}
+}
+
+func (s *Signer) sign(ctx context.Context, msg string) (string, error) {
+	der, err := s.source.SignASN1(ctx, hash(msg))
 	if err != nil {
 		return "", fmt.Errorf("skadnetwork: sign message error: %w", err)
 	}
@@ -203,11 +364,11 @@ func (s *Signer) sign(msg string) (string, error) {
 
 func (s *Signer) Sign(p *Params) (string, error) {
 	msg := strings.Join(p.toItems(), separator)
-	return s.sign(msg)
+	return s.sign(context.Background(), msg)
 }
 
 func (s *Signer) Verify(p *Params, sig string) (bool, error) {
-	return verify(&s.key.PublicKey, p.toItems(), sig)
+	return verify(s.source.Public(), p.toItems(), sig)
 }
 
 // https://developer.apple.com/documentation/storekit/skadnetwork/verifying_an_install-validation_postback#3599761